@@ -0,0 +1,62 @@
+package sbinary
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// Append encodes obj using order and appends the result to dst, returning the
+// extended buffer. It behaves like [Marshal], but lets the caller reuse an
+// existing buffer and avoid an allocation, mirroring [encoding/binary.Append].
+func Append(dst []byte, obj any, order binary.ByteOrder) ([]byte, error) {
+	val := reflect.ValueOf(obj)
+
+	w := &sliceWriter{buf: dst}
+	if err := encode(val, w, order, false); err != nil {
+		return nil, err
+	}
+
+	return w.buf, nil
+}
+
+// Decode reads obj out of src using order and returns the number of bytes
+// consumed, mirroring [encoding/binary.Decode]. Unlike [Unmarshal] it reads
+// directly out of src instead of wrapping it in a [bytes.Reader].
+func Decode(src []byte, obj any, order binary.ByteOrder, opts ...DecoderOptions) (int, error) {
+	val := reflect.ValueOf(obj)
+	if val.Kind() != reflect.Pointer {
+		return 0, fmt.Errorf("obj must be a pointer, got: %v", val.Kind())
+	}
+
+	if val.IsNil() {
+		return 0, fmt.Errorf("obj must be a valid pointer, got nil")
+	}
+
+	var decOpts DecoderOptions
+	if len(opts) > 0 {
+		decOpts = opts[0]
+	}
+
+	r := &sliceReader{buf: src}
+	ctx := &decodeCtx{order: order, opts: decOpts}
+	if decOpts.MaxTotalBytes > 0 {
+		// remaining reports budget left against bytes actually consumed so
+		// far, not against len(src): src may hold further messages or
+		// trailing bytes after the one being decoded here, and those must
+		// not count against this call's cap.
+		ctx.remaining = func() int {
+			return decOpts.MaxTotalBytes - (len(src) - len(r.buf))
+		}
+	}
+	if err := decode(val.Elem(), r, ctx, nil, false); err != nil {
+		return 0, err
+	}
+
+	consumed := len(src) - len(r.buf)
+	if decOpts.MaxTotalBytes > 0 && consumed > decOpts.MaxTotalBytes {
+		return 0, &ErrLimitExceeded{Limit: "total bytes read", Value: consumed, Max: decOpts.MaxTotalBytes}
+	}
+
+	return consumed, nil
+}
@@ -0,0 +1,172 @@
+package sbinary
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+)
+
+// sliceWriter is a fast-path io.Writer that appends directly to a []byte
+// instead of going through [binary.Write]'s reflection.
+type sliceWriter struct {
+	buf []byte
+}
+
+func (w *sliceWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// sliceReader is a fast-path io.Reader that reads directly out of a []byte
+// instead of going through [binary.Read]'s reflection.
+type sliceReader struct {
+	buf []byte
+}
+
+func (r *sliceReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next returns the next n bytes of r without copying, advancing r past them.
+func (r *sliceReader) next(n int) ([]byte, error) {
+	if len(r.buf) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
+
+// putNumeric writes val into tmp in order's byte order, dispatching on
+// val.Kind() via the boxing-free reflect.Value accessors (Bool/Int/Uint/
+// Float) rather than val.Interface(), and returns the number of bytes
+// written. tmp must have a length of at least 8.
+func putNumeric(tmp []byte, order binary.ByteOrder, val reflect.Value) int {
+	switch val.Kind() {
+	case reflect.Bool:
+		if val.Bool() {
+			tmp[0] = 1
+		} else {
+			tmp[0] = 0
+		}
+		return 1
+	case reflect.Int8:
+		tmp[0] = byte(val.Int())
+		return 1
+	case reflect.Uint8:
+		tmp[0] = byte(val.Uint())
+		return 1
+	case reflect.Int16:
+		order.PutUint16(tmp, uint16(val.Int()))
+		return 2
+	case reflect.Uint16:
+		order.PutUint16(tmp, uint16(val.Uint()))
+		return 2
+	case reflect.Int32:
+		order.PutUint32(tmp, uint32(val.Int()))
+		return 4
+	case reflect.Uint32:
+		order.PutUint32(tmp, uint32(val.Uint()))
+		return 4
+	case reflect.Int64:
+		order.PutUint64(tmp, uint64(val.Int()))
+		return 8
+	case reflect.Uint64:
+		order.PutUint64(tmp, val.Uint())
+		return 8
+	case reflect.Float32:
+		order.PutUint32(tmp, math.Float32bits(float32(val.Float())))
+		return 4
+	case reflect.Float64:
+		order.PutUint64(tmp, math.Float64bits(val.Float()))
+		return 8
+	default:
+		return 0
+	}
+}
+
+// readNumericFast reads a T out of next without going through reflection.
+// next returns the requested number of bytes without copying, e.g.
+// [sliceReader.next] or a closure over [bytes.Buffer.Next].
+func readNumericFast[T fixedNumeric](next func(int) ([]byte, error), order binary.ByteOrder) (T, error) {
+	var zero T
+
+	switch any(zero).(type) {
+	case bool:
+		b, err := next(1)
+		if err != nil {
+			return zero, err
+		}
+		return any(b[0] != 0).(T), nil
+	case int8:
+		b, err := next(1)
+		if err != nil {
+			return zero, err
+		}
+		return any(int8(b[0])).(T), nil
+	case uint8:
+		b, err := next(1)
+		if err != nil {
+			return zero, err
+		}
+		return any(b[0]).(T), nil
+	case int16:
+		b, err := next(2)
+		if err != nil {
+			return zero, err
+		}
+		return any(int16(order.Uint16(b))).(T), nil
+	case uint16:
+		b, err := next(2)
+		if err != nil {
+			return zero, err
+		}
+		return any(order.Uint16(b)).(T), nil
+	case int32:
+		b, err := next(4)
+		if err != nil {
+			return zero, err
+		}
+		return any(int32(order.Uint32(b))).(T), nil
+	case uint32:
+		b, err := next(4)
+		if err != nil {
+			return zero, err
+		}
+		return any(order.Uint32(b)).(T), nil
+	case int64:
+		b, err := next(8)
+		if err != nil {
+			return zero, err
+		}
+		return any(int64(order.Uint64(b))).(T), nil
+	case uint64:
+		b, err := next(8)
+		if err != nil {
+			return zero, err
+		}
+		return any(order.Uint64(b)).(T), nil
+	case float32:
+		b, err := next(4)
+		if err != nil {
+			return zero, err
+		}
+		return any(math.Float32frombits(order.Uint32(b))).(T), nil
+	case float64:
+		b, err := next(8)
+		if err != nil {
+			return zero, err
+		}
+		return any(math.Float64frombits(order.Uint64(b))).(T), nil
+	default:
+		return zero, io.ErrUnexpectedEOF
+	}
+}
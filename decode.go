@@ -6,15 +6,14 @@ import (
 	"fmt"
 	"io"
 	"reflect"
-	"strings"
 )
 
 const _tag = "sbin"
 
 // Unmarshal unmarshals data using provided byte order and stores result into pointer obj.
 // See [Decoder.Decode] for details.
-func Unmarshal(data []byte, obj any, order binary.ByteOrder) error {
-	if err := NewDecoder(bytes.NewReader(data)).Decode(obj, order); err != nil {
+func Unmarshal(data []byte, obj any, order binary.ByteOrder, opts ...DecoderOptions) error {
+	if err := NewDecoder(bytes.NewReader(data), opts...).Decode(obj, order); err != nil {
 		return err
 	}
 
@@ -23,19 +22,33 @@ func Unmarshal(data []byte, obj any, order binary.ByteOrder) error {
 
 // Decoder decodes incoming bytes into Go objects.
 type Decoder struct {
-	r io.Reader
+	r    io.Reader
+	opts DecoderOptions
 }
 
-// NewDecoder created a [Decoder] that will use r for the input.
-func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{r: r}
+// NewDecoder created a [Decoder] that will use r for the input. By default no
+// limits are enforced; pass [DecoderOptions] to bound how much the decoder
+// will trust length-prefixed and nested fields in r, which matters when r is
+// hostile or untrusted input.
+func NewDecoder(r io.Reader, opts ...DecoderOptions) *Decoder {
+	d := &Decoder{r: r}
+	if len(opts) > 0 {
+		d.opts = opts[0]
+	}
+
+	return d
 }
 
 // Decode decodes obj. For numberic fields it uses provided byte order.
 // It can be called multiple times.
 //
-// Currently only slices, arrays, strings, numeric types (including bools) and structures are supported.
-// For other types and any custom logic you can implement [CustomEncoder] and [CustomDecoder].
+// Currently only slices, arrays, maps, strings, numeric types (including bools) and structures
+// are supported. For other types and any custom logic you can implement [CustomEncoder] and
+// [CustomDecoder].
+//
+// Interface fields are ignored unless their static type was registered via [Register], in
+// which case the concrete value's type byte is read first and used to allocate and decode
+// into the right concrete type.
 //
 // Pointers treated as just values. Nil pointer will be encoded as if it was
 // valid pointer to zero-value (e.g. *int64 will be encoded as just int64(0)).
@@ -44,8 +57,8 @@ func NewDecoder(r io.Reader) *Decoder {
 // Use of int and uint types are not recommended, because the sending and receiving machines can
 // have different architecture (32 or 64 bit). Use fixed-siz types like uin32, int64, etc.
 //
-// When decoding slices or strings, there must be another integer field (any signed or
-// unsigned type) before slice field with tag `sbin:"lenof:<TargetField>"`, otherwise
+// When decoding slices, strings or maps, there must be another integer field (any signed or
+// unsigned type) before that field with tag `sbin:"lenof:<TargetField>"`, otherwise
 // error will be returned, e.g.:
 //
 //	type String struct {
@@ -67,6 +80,18 @@ func NewDecoder(r io.Reader) *Decoder {
 // encoded as-is, e.g. when encoding `String{Len: 3, Data: "4444"}` will be encoded as `encode(3) + encode("4444")`.
 //
 // For slices, if length field is zero, then the data field will be set to zero-length slice (not nil).
+//
+// Maps are supported the same way, with the length field counting entries. The map key must be
+// a string or integer type; on encode, keys are sorted (bytewise for strings, by value for
+// integers) so the output is deterministic across runs.
+//
+// Integer fields (including length fields) can be tagged `sbin:"varint"`, e.g.
+// `sbin:"lenof:Data,varint"`, to be encoded as a LEB128 varint instead of their fixed width.
+// Signed integers are zigzag-encoded first so that small negative numbers stay small on the wire.
+//
+// If d was built with [DecoderOptions], a `lenof:`-driven slice, string or array, or a struct
+// nesting depth, that would exceed them returns [ErrLimitExceeded] instead of proceeding
+// with a potentially huge allocation.
 func (d *Decoder) Decode(obj any, order binary.ByteOrder) error {
 	val := reflect.ValueOf(obj)
 	if val.Kind() != reflect.Pointer {
@@ -79,17 +104,35 @@ func (d *Decoder) Decode(obj any, order binary.ByteOrder) error {
 
 	// dereference
 	val = val.Elem()
-	return decode(val, d.r, order, nil)
+
+	from := d.r
+	ctx := &decodeCtx{order: order, opts: d.opts}
+	if d.opts.MaxTotalBytes > 0 {
+		lr := &limitedReader{r: d.r, limit: d.opts.MaxTotalBytes, remaining: d.opts.MaxTotalBytes}
+		from = lr
+		ctx.remaining = lr.Remaining
+	}
+
+	return decode(val, from, ctx, nil, false)
 }
 
-func decode(val reflect.Value, from io.Reader, order binary.ByteOrder, size *int) error {
+func decode(val reflect.Value, from io.Reader, ctx *decodeCtx, size *int, varint bool) error {
 	// TODO: check for unexpected EOF
+	order := ctx.order
 
 	switch v := val.Addr().Interface().(type) {
 	case CustomDecoder:
 		return v.Decode(from, order)
 	}
 
+	if varint {
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return decodeVarint(val, from)
+		}
+	}
+
 	switch val.Kind() {
 	case reflect.Bool:
 		return decodeNumeric[bool](val, from, order)
@@ -133,6 +176,9 @@ func decode(val reflect.Value, from io.Reader, order binary.ByteOrder, size *int
 		if *size <= 0 {
 			return nil // maybe set to 0 len slice if len is 0?
 		}
+		if err := ctx.guardAlloc(*size, "string length", ctx.opts.MaxStringLength); err != nil {
+			return err
+		}
 
 		buf := make([]byte, *size)
 		_, err := io.ReadFull(from, buf)
@@ -151,9 +197,12 @@ func decode(val reflect.Value, from io.Reader, order binary.ByteOrder, size *int
 			val.Set(reflect.MakeSlice(val.Type(), 0, 0))
 			return nil // maybe set to 0 len slice if len is 0?
 		}
+		if err := ctx.guardAlloc(*size, "slice length", ctx.opts.MaxSliceLength); err != nil {
+			return err
+		}
 
 		if val.Type().Elem().Kind() != reflect.Uint8 {
-			return decodeSliceOrArray(val, from, order, *size)
+			return decodeSliceOrArray(val, from, ctx, *size)
 		}
 
 		buf := make([]byte, *size)
@@ -167,8 +216,11 @@ func decode(val reflect.Value, from io.Reader, order binary.ByteOrder, size *int
 
 	case reflect.Array:
 		arrSize := val.Type().Len()
+		if err := ctx.guardAlloc(arrSize, "array length", ctx.opts.MaxSliceLength); err != nil {
+			return err
+		}
 		if val.Type().Elem().Kind() != reflect.Uint8 {
-			return decodeSliceOrArray(val, from, order, arrSize)
+			return decodeSliceOrArray(val, from, ctx, arrSize)
 		}
 
 		buf := make([]byte, arrSize)
@@ -180,48 +232,95 @@ func decode(val reflect.Value, from io.Reader, order binary.ByteOrder, size *int
 		reflect.Copy(val, reflect.ValueOf(buf))
 		return nil
 
-	case reflect.Struct:
-		// lengths of arbitary-sized fields, specified by tags
-		lens := make(map[string]int)
+	case reflect.Map:
+		if size == nil {
+			return fmt.Errorf("size of map not specified")
+		}
+		if *size <= 0 {
+			val.Set(reflect.MakeMapWithSize(val.Type(), 0))
+			return nil
+		}
+		if err := ctx.guardAlloc(*size, "map length", ctx.opts.MaxSliceLength); err != nil {
+			return err
+		}
 
-		for i := range val.NumField() {
-			fieldVal := val.Field(i)
-			fieldInfo := val.Type().Field(i)
-			fieldTag := fieldInfo.Tag.Get(_tag)
+		return decodeMap(val, from, ctx, *size)
 
-			if fieldTag == "-" || !fieldInfo.IsExported() {
-				continue
-			}
+	case reflect.Struct:
+		if max := ctx.opts.MaxNestingDepth; max > 0 && ctx.depth >= max {
+			return &ErrLimitExceeded{Limit: "nesting depth", Value: ctx.depth + 1, Max: max}
+		}
+		ctx.depth++
+		defer func() { ctx.depth-- }()
+
+		plan := planFor(val.Type())
+
+		// lens[i] holds the decoded length for plan.fields[i], set by an
+		// earlier field whose lenTarget points at i; -1 means not set yet.
+		// Indexed by the plan's resolved lenTarget instead of a per-call
+		// map keyed by field name.
+		lens := make([]int, len(plan.fields))
+		for i := range lens {
+			lens[i] = -1
+		}
+
+		for i, f := range plan.fields {
+			fieldVal := val.Field(f.index)
 
 			var err error
-			if size, ok := lens[fieldInfo.Name]; ok {
-				err = decode(fieldVal, from, order, &size)
+			if size := lens[i]; size >= 0 {
+				err = decode(fieldVal, from, ctx, &size, f.opts.varint)
 			} else {
-				err = decode(fieldVal, from, order, nil)
+				err = decode(fieldVal, from, ctx, nil, f.opts.varint)
 			}
 			if err != nil {
-				return fmt.Errorf("can't decode field %v (%v): %w", fieldInfo.Name, fieldVal.Type().Name(), err)
+				return fmt.Errorf("can't decode field %v (%v): %w", f.name, fieldVal.Type().Name(), err)
 			}
 
-			// if current field specifies the length of another field - save it into the map
-			if anotherField, size, ok := sizeOfAnotherField(fieldVal, fieldTag); ok {
-				lens[anotherField] = size
+			// if current field specifies the length of another field - save it for that field's turn
+			if f.lenTarget >= 0 {
+				if size, ok := lenFieldValue(fieldVal); ok {
+					lens[f.lenTarget] = size
+				}
 			}
 		}
 
 	case reflect.Pointer:
 		val.Set(reflect.New(val.Type().Elem()))
 
-		return decode(val.Elem(), from, order, size)
+		return decode(val.Elem(), from, ctx, size, varint)
 
 	case reflect.Interface:
-		// ignored
+		concretes, registered := ifaceTypes[val.Type()]
+		if !registered {
+			// no concretes were ever passed to Register for this interface
+			// type, so leave it alone, as before
+			return nil
+		}
+
+		tag, err := readNumeric[byte](from, order)
+		if err != nil {
+			return fmt.Errorf("can't read interface type byte: %w", err)
+		}
+
+		concreteType, ok := concretes[tag]
+		if !ok {
+			return fmt.Errorf("unknown type byte %d for interface %v, see Register", tag, val.Type())
+		}
+
+		concretePtr := reflect.New(concreteType)
+		if err := decode(concretePtr.Elem(), from, ctx, nil, false); err != nil {
+			return fmt.Errorf("can't decode concrete type %v: %w", concreteType, err)
+		}
+
+		val.Set(concretePtr.Elem())
+		return nil
 	}
 
 	return nil
 }
 
-func decodeSliceOrArray(val reflect.Value, from io.Reader, order binary.ByteOrder, size int) error {
+func decodeSliceOrArray(val reflect.Value, from io.Reader, ctx *decodeCtx, size int) error {
 	if val.Type().Kind() == reflect.Slice {
 		val.Grow(size)
 		val.SetLen(size)
@@ -229,32 +328,46 @@ func decodeSliceOrArray(val reflect.Value, from io.Reader, order binary.ByteOrde
 
 	for i := range size {
 		item := val.Index(i)
-		if err := decode(item, from, order, nil); err != nil {
+		if err := decode(item, from, ctx, nil, false); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func sizeOfAnotherField(val reflect.Value, tag string) (string, int, bool) {
-	var size int
+// lenFieldValue extracts the length encoded in a lenof source field's
+// decoded value.
+func lenFieldValue(val reflect.Value) (int, bool) {
 	switch {
 	case val.CanInt():
-		size = int(val.Int())
+		return int(val.Int()), true
 	case val.CanUint():
-		size = int(val.Uint())
+		return int(val.Uint()), true
 	case val.Kind() == reflect.Pointer: // so sizes could be specified as pointers, e.g. *int64 or event *****int64
-		return sizeOfAnotherField(val.Elem(), tag)
+		return lenFieldValue(val.Elem())
 	default:
-		return "", 0, false
+		return 0, false
 	}
+}
 
-	targetField, ok := strings.CutPrefix(tag, "lenof:")
-	if !ok {
-		return "", 0, false
+// decodeVarint reads a LEB128 varint into val, zigzag-decoding it first for
+// signed integer kinds.
+func decodeVarint(val reflect.Value, from io.Reader) error {
+	u, err := readUvarint(from)
+	if err != nil {
+		return fmt.Errorf("can't read varint: %w", err)
 	}
 
-	return targetField, size, true
+	switch {
+	case val.CanInt():
+		val.SetInt(zigzagDecode(u))
+	case val.CanUint():
+		val.SetUint(u)
+	default:
+		return fmt.Errorf("varint option is only valid for integer fields, got %v", val.Kind())
+	}
+
+	return nil
 }
 
 type fixedNumeric interface {
@@ -275,6 +388,21 @@ func decodeNumeric[T fixedNumeric](val reflect.Value, r io.Reader, order binary.
 }
 
 func readNumeric[T fixedNumeric](r io.Reader, order binary.ByteOrder) (T, error) {
+	switch src := r.(type) {
+	case *sliceReader:
+		v, err := readNumericFast[T](src.next, order)
+		if err != nil {
+			return v, fmt.Errorf("can't read %T: %w", v, err)
+		}
+		return v, nil
+	case *bytes.Buffer:
+		v, err := readNumericFast[T](bufNext(src), order)
+		if err != nil {
+			return v, fmt.Errorf("can't read %T: %w", v, err)
+		}
+		return v, nil
+	}
+
 	var v T
 	if err := binary.Read(r, order, &v); err != nil {
 		return v, fmt.Errorf("can't read %T: %w", v, err)
@@ -282,3 +410,15 @@ func readNumeric[T fixedNumeric](r io.Reader, order binary.ByteOrder) (T, error)
 
 	return v, nil
 }
+
+// bufNext returns a next func (see [readNumericFast]) that reads out of buf
+// without copying, erroring like [sliceReader.next] if buf is exhausted
+// early instead of silently returning a short read.
+func bufNext(buf *bytes.Buffer) func(int) ([]byte, error) {
+	return func(n int) ([]byte, error) {
+		if buf.Len() < n {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return buf.Next(n), nil
+	}
+}
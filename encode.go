@@ -41,12 +41,12 @@ func NewEncoder(w io.Writer) *Encoder {
 func (e *Encoder) Encode(data any, order binary.ByteOrder) error {
 	val := reflect.ValueOf(data)
 
-	return encode(val, e.w, order)
+	return encode(val, e.w, order, false)
 }
 
 var customEncoderType = reflect.TypeFor[CustomEncoder]()
 
-func encode(val reflect.Value, into io.Writer, order binary.ByteOrder) error {
+func encode(val reflect.Value, into io.Writer, order binary.ByteOrder, varint bool) error {
 	valType := val.Type()
 	if reflect.PointerTo(valType).Implements(customEncoderType) {
 		// Handle custom unmarshaler with reflection to ensure pointer
@@ -55,6 +55,14 @@ func encode(val reflect.Value, into io.Writer, order binary.ByteOrder) error {
 		return ptr.Interface().(CustomEncoder).Encode(into, order)
 	}
 
+	if varint {
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return encodeVarint(val, into)
+		}
+	}
+
 	switch val.Kind() {
 	case reflect.Bool:
 		return writeNumeric[bool](into, order, val)
@@ -114,21 +122,18 @@ func encode(val reflect.Value, into io.Writer, order binary.ByteOrder) error {
 
 		return encodeSliceOrArray(val, into, order)
 
+	case reflect.Map:
+		return encodeMap(val, into, order)
+
 	case reflect.Struct:
-		for i := range val.NumField() {
-			fieldVal := val.Field(i)
-			// for debug purposes
-			fieldInfo := val.Type().Field(i)
-			fieldTag := fieldInfo.Tag.Get(_tag)
-			_ = fieldTag
-
-			if fieldTag == "-" || !fieldInfo.IsExported() {
-				continue
-			}
+		plan := planFor(valType)
+
+		for _, f := range plan.fields {
+			fieldVal := val.Field(f.index)
 
-			err := encode(fieldVal, into, order)
+			err := encode(fieldVal, into, order, f.opts.varint)
 			if err != nil {
-				return fmt.Errorf("can't encode field %v (%v): %w", fieldInfo.Name, fieldVal.Type().Name(), err)
+				return fmt.Errorf("can't encode field %v (%v): %w", f.name, fieldVal.Type().Name(), err)
 			}
 		}
 
@@ -139,10 +144,31 @@ func encode(val reflect.Value, into io.Writer, order binary.ByteOrder) error {
 			val = reflect.New(val.Type().Elem())
 		}
 
-		return encode(val.Elem(), into, order)
+		return encode(val.Elem(), into, order, varint)
 
 	case reflect.Interface:
-		// ignored
+		tags, registered := ifaceTags[valType]
+		if !registered {
+			// no concretes were ever passed to Register for this interface
+			// type, so leave it alone, as before
+			return nil
+		}
+
+		if val.IsNil() {
+			return fmt.Errorf("can't encode nil interface of registered type %v, see Register", valType)
+		}
+
+		concrete := val.Elem()
+		tag, ok := tags[concrete.Type()]
+		if !ok {
+			return fmt.Errorf("type %v is not registered for interface %v, see Register", concrete.Type(), valType)
+		}
+
+		if err := writeNumeric[byte](into, order, reflect.ValueOf(tag)); err != nil {
+			return err
+		}
+
+		return encode(concrete, into, order, false)
 	}
 
 	return nil
@@ -151,7 +177,7 @@ func encode(val reflect.Value, into io.Writer, order binary.ByteOrder) error {
 func encodeSliceOrArray(val reflect.Value, into io.Writer, order binary.ByteOrder) error {
 	for i := 0; i < val.Len(); i++ {
 		item := val.Index(i)
-		if err := encode(item, into, order); err != nil {
+		if err := encode(item, into, order, false); err != nil {
 			return err
 		}
 	}
@@ -160,9 +186,35 @@ func encodeSliceOrArray(val reflect.Value, into io.Writer, order binary.ByteOrde
 }
 
 func writeNumeric[T fixedNumeric](into io.Writer, order binary.ByteOrder, val reflect.Value) error {
+	switch w := into.(type) {
+	case *sliceWriter:
+		var tmp [8]byte
+		n := putNumeric(tmp[:], order, val)
+		w.buf = append(w.buf, tmp[:n]...)
+		return nil
+	case *bytes.Buffer:
+		var tmp [8]byte
+		n := putNumeric(tmp[:], order, val)
+		_, err := w.Write(tmp[:n])
+		return err
+	}
+
 	return binary.Write(into, order, val.Interface())
 }
 
+// encodeVarint writes val as a LEB128 varint, zigzag-encoding it first for
+// signed integer kinds.
+func encodeVarint(val reflect.Value, into io.Writer) error {
+	switch {
+	case val.CanInt():
+		return writeUvarint(into, zigzagEncode(val.Int()))
+	case val.CanUint():
+		return writeUvarint(into, val.Uint())
+	default:
+		return fmt.Errorf("varint option is only valid for integer fields, got %v", val.Kind())
+	}
+}
+
 func arrayToSlice(arr reflect.Value) reflect.Value {
 	ptr := reflect.New(arr.Type()).Elem()
 	ptr.Set(arr)
@@ -0,0 +1,94 @@
+package sbinary
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DecoderOptions bounds how much a [Decoder] will trust length-prefixed and
+// nested fields in the input, so that hostile input can't make it attempt a
+// huge allocation or recurse without bound. A zero value means no limit,
+// matching the behavior before DecoderOptions existed.
+type DecoderOptions struct {
+	// MaxTotalBytes bounds the number of bytes a single Decode call may read.
+	MaxTotalBytes int
+	// MaxSliceLength bounds the number of elements a `lenof`-prefixed slice,
+	// a `lenof`-prefixed map, or an array may have.
+	MaxSliceLength int
+	// MaxStringLength bounds the number of bytes a `lenof`-prefixed string may have.
+	MaxStringLength int
+	// MaxNestingDepth bounds how deeply structs may be nested.
+	MaxNestingDepth int
+}
+
+// ErrLimitExceeded is returned when decoding would exceed a configured
+// [DecoderOptions] limit.
+type ErrLimitExceeded struct {
+	Limit string
+	Value int
+	Max   int
+}
+
+func (e *ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("sbinary: %s %d exceeds configured max of %d", e.Limit, e.Value, e.Max)
+}
+
+// decodeCtx carries the state that's threaded through a single Decode call:
+// the byte order, the configured limits, and the current nesting depth.
+type decodeCtx struct {
+	order binary.ByteOrder
+	opts  DecoderOptions
+	depth int
+
+	// remaining, when set, reports how many more bytes may be read before
+	// MaxTotalBytes is exceeded. It's consulted by guardAlloc so that a
+	// hostile length prefix is rejected before the allocation it would
+	// drive, not only once the subsequent read runs dry.
+	remaining func() int
+}
+
+// guardAlloc returns [ErrLimitExceeded] if n exceeds max (when max > 0), or
+// exceeds the remaining MaxTotalBytes budget (when one is configured).
+// Callers must call this before allocating or growing by n bytes/elements.
+func (ctx *decodeCtx) guardAlloc(n int, limit string, max int) error {
+	if max > 0 && n > max {
+		return &ErrLimitExceeded{Limit: limit, Value: n, Max: max}
+	}
+
+	if ctx.remaining != nil {
+		if r := ctx.remaining(); n > r {
+			return &ErrLimitExceeded{Limit: "total bytes read", Value: n, Max: r}
+		}
+	}
+
+	return nil
+}
+
+// limitedReader wraps an io.Reader, never reads more than limit bytes from
+// it, and returns [ErrLimitExceeded] for any Read attempted once limit bytes
+// have been read, instead of silently reading forever or returning a plain EOF.
+type limitedReader struct {
+	r         io.Reader
+	limit     int
+	remaining int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, &ErrLimitExceeded{Limit: "total bytes read", Max: l.limit}
+	}
+
+	if len(p) > l.remaining {
+		p = p[:l.remaining]
+	}
+
+	n, err := l.r.Read(p)
+	l.remaining -= n
+	return n, err
+}
+
+// Remaining reports how many more bytes l will allow to be read.
+func (l *limitedReader) Remaining() int {
+	return l.remaining
+}
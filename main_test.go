@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -27,6 +28,24 @@ func TestEncodeDecode(t *testing.T) {
 		}, nil)
 		test(t, [0]String{}, nil)
 	})
+	t.Run("map", func(t *testing.T) {
+		test(t, Map{Len: 3, Data: map[uint32]int64{1: 10, 2: 20, 3: 30}}, nil)
+		test(t, Map{Len: 0, Data: map[uint32]int64{}}, nil)
+	})
+	t.Run("varint", func(t *testing.T) {
+		test(t, Varint{Signed: 0, Unsigned: 0}, nil)
+		test(t, Varint{Signed: -1, Unsigned: 1}, nil)
+		test(t, Varint{Signed: -64, Unsigned: 64}, nil)
+		test(t, Varint{Signed: math.MinInt64, Unsigned: math.MaxUint64}, nil)
+		test(t, Varint{Signed: math.MaxInt64, Unsigned: math.MaxUint64}, nil)
+
+		test(t, VarintLenString{Len: 5, Data: "hello"}, nil)
+		test(t, VarintLenString{Len: 0, Data: ""}, nil)
+	})
+	t.Run("interface", func(t *testing.T) {
+		test(t, Envelope{Msg: Ping{Seq: 7}}, nil)
+		test(t, Envelope{Msg: Pong{Seq: 8}}, nil)
+	})
 	t.Run("custom", func(t *testing.T) {
 		test(t, Custom{Optional: ptr(777.0)}, nil)
 		test(t, Custom{Optional: nil}, nil)
@@ -146,6 +165,85 @@ func TestEncodeDecode(t *testing.T) {
 	})
 }
 
+func TestDecoderOptions(t *testing.T) {
+	t.Run("within limits roundtrips", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		input := String{Len: 5, Data: "hello"}
+		require.Nil(t, NewEncoder(buf).Encode(input, binary.BigEndian))
+
+		opts := DecoderOptions{MaxStringLength: 16, MaxTotalBytes: 64}
+		var decoded String
+		require.Nil(t, NewDecoder(buf, opts).Decode(&decoded, binary.BigEndian))
+		require.Equal(t, input, decoded)
+	})
+
+	t.Run("hostile length prefix is rejected before allocating", func(t *testing.T) {
+		// A Len that claims ~4 GiB of string data, with no actual data
+		// behind it. Without a pre-allocation guard this would try to
+		// make([]byte, 0xFFFFFFFF) before ever reading (and failing on) the
+		// missing data.
+		var buf bytes.Buffer
+		require.Nil(t, binary.Write(&buf, binary.BigEndian, uint32(0xFFFFFFFF)))
+
+		opts := DecoderOptions{MaxStringLength: 1024}
+		var decoded String
+		err := NewDecoder(&buf, opts).Decode(&decoded, binary.BigEndian)
+
+		var limitErr *ErrLimitExceeded
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, "string length", limitErr.Limit)
+	})
+
+	t.Run("slice length over cap is rejected", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		input := Slice[String]{Len: 3, Data: []String{
+			{Len: 5, Data: "hello"},
+			{Len: 4, Data: "hell"},
+			{Len: 3, Data: "hel"},
+		}}
+		require.Nil(t, NewEncoder(buf).Encode(input, binary.BigEndian))
+
+		opts := DecoderOptions{MaxSliceLength: 2}
+		var decoded Slice[String]
+		err := NewDecoder(buf, opts).Decode(&decoded, binary.BigEndian)
+
+		var limitErr *ErrLimitExceeded
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, "slice length", limitErr.Limit)
+	})
+
+	t.Run("package-level Decode enforces MaxTotalBytes", func(t *testing.T) {
+		data, err := Append(nil, String{Len: 5, Data: "hello"}, binary.BigEndian)
+		require.Nil(t, err)
+
+		var decoded String
+		_, err = Decode(data, &decoded, binary.BigEndian, DecoderOptions{MaxTotalBytes: len(data) - 1})
+
+		var limitErr *ErrLimitExceeded
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, "total bytes read", limitErr.Limit)
+	})
+
+	t.Run("MaxTotalBytes is enforced against bytes consumed, not len(src)", func(t *testing.T) {
+		// data holds one 9-byte String message followed by 9 bytes of
+		// trailing data (as if more messages followed in the same buffer).
+		// MaxTotalBytes must bound what this Decode call itself reads, not
+		// the size of the whole buffer it was handed.
+		msg, err := Append(nil, String{Len: 5, Data: "hello"}, binary.BigEndian)
+		require.Nil(t, err)
+		require.Len(t, msg, 9)
+
+		data := append(append([]byte{}, msg...), msg...)
+		require.Len(t, data, 18)
+
+		var decoded String
+		n, err := Decode(data, &decoded, binary.BigEndian, DecoderOptions{MaxTotalBytes: 12})
+		require.Nil(t, err)
+		require.Equal(t, 9, n)
+		require.Equal(t, String{Len: 5, Data: "hello"}, decoded)
+	})
+}
+
 func test[T any](t *testing.T, input T, middle func(v *T)) {
 	buf := bytes.NewBuffer(nil)
 	require.Nil(t, NewEncoder(buf).Encode(input, binary.BigEndian))
@@ -192,6 +290,88 @@ func BenchmarkEncodeDecode(b *testing.B) {
 	}
 }
 
+// TestAppendDecodeLowAllocation checks that the Append/Decode fast path
+// (sliceWriter/sliceReader) allocates less than going through NewEncoder/
+// NewDecoder over a writer/reader that hides its concrete type (see
+// opaqueWriter), which falls back to encoding/binary and boxes every numeric
+// field. It doesn't assert a literal zero, since decoding
+// still does a handful of small reflect-driven allocations (e.g. converting
+// back into the destination field), but the fast path must not pay for
+// per-field boxing on top of those.
+func TestAppendDecodeLowAllocation(t *testing.T) {
+	req := Numerics{
+		Int: -1, Int8: -2, Int16: -3, Int32: -4, Int64: -5,
+		Uint: 6, Uint8: 7, Uint16: 8, Uint32: 9, Uint64: 10,
+		Float32: 11.11, Float64: 12.12,
+	}
+
+	data, err := Append(nil, req, binary.BigEndian)
+	require.Nil(t, err)
+
+	fastEncodeAllocs := testing.AllocsPerRun(100, func() {
+		buf := make([]byte, 0, len(data))
+		if _, err := Append(buf, req, binary.BigEndian); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	slowEncodeAllocs := testing.AllocsPerRun(100, func() {
+		buf := &opaqueWriter{bytes.NewBuffer(make([]byte, 0, len(data)))}
+		if err := NewEncoder(buf).Encode(req, binary.BigEndian); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	require.Less(t, fastEncodeAllocs, slowEncodeAllocs)
+
+	fastDecodeAllocs := testing.AllocsPerRun(100, func() {
+		var decoded Numerics
+		if _, err := Decode(data, &decoded, binary.BigEndian); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	slowDecodeAllocs := testing.AllocsPerRun(100, func() {
+		var decoded Numerics
+		r := &opaqueReader{bytes.NewReader(data)}
+		if err := NewDecoder(r).Decode(&decoded, binary.BigEndian); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	require.Less(t, fastDecodeAllocs, slowDecodeAllocs)
+}
+
+// opaqueWriter/opaqueReader wrap an io.Writer/io.Reader without exposing the
+// underlying concrete type, so writeNumeric/readNumeric can't type-assert
+// their way onto a fast path and must fall back to [encoding/binary]'s
+// reflection-based Write/Read. Used to give TestAppendDecodeLowAllocation a
+// slow baseline to compare the fast path against.
+type opaqueWriter struct{ io.Writer }
+type opaqueReader struct{ io.Reader }
+
+func BenchmarkAppendDecode(b *testing.B) {
+	req := Numerics{
+		Int: -1, Int8: -2, Int16: -3, Int32: -4, Int64: -5,
+		Uint: 6, Uint8: 7, Uint16: 8, Uint32: 9, Uint64: 10,
+		Float32: 11.11, Float64: 12.12,
+	}
+
+	buf := make([]byte, 0, 128)
+	for range b.N {
+		var err error
+		buf, err = Append(buf[:0], req, binary.BigEndian)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var decoded Numerics
+		if _, err := Decode(buf, &decoded, binary.BigEndian); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 type Request struct {
 	MessageSize uint32
 	Custom      Custom
@@ -250,6 +430,45 @@ type Slice[T any] struct {
 	Data []T
 }
 
+type Map struct {
+	Len  uint32 `sbin:"lenof:Data"`
+	Data map[uint32]int64
+}
+
+type Varint struct {
+	Signed   int64  `sbin:"varint"`
+	Unsigned uint64 `sbin:"varint"`
+}
+
+type VarintLenString struct {
+	Len  uint32 `sbin:"lenof:Data,varint"`
+	Data string
+}
+
+type Message interface {
+	isMessage()
+}
+
+type Ping struct {
+	Seq int32
+}
+
+func (Ping) isMessage() {}
+
+type Pong struct {
+	Seq int32
+}
+
+func (Pong) isMessage() {}
+
+type Envelope struct {
+	Msg Message
+}
+
+func init() {
+	Register((*Message)(nil), Ping{}, Pong{})
+}
+
 type Custom struct {
 	Optional *float64
 }
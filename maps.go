@@ -0,0 +1,87 @@
+package sbinary
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// isSortableMapKey reports whether kind can be used as a map key, i.e. a
+// string or any integer kind. Other kinds (bool, float, struct, ...) are
+// rejected since they have no natural deterministic ordering here.
+func isSortableMapKey(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// sortMapKeys sorts keys bytewise for strings and by natural order for
+// integers, so Encode output is deterministic across runs.
+func sortMapKeys(keys []reflect.Value, kind reflect.Kind) {
+	sort.Slice(keys, func(i, j int) bool {
+		switch kind {
+		case reflect.String:
+			return keys[i].String() < keys[j].String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return keys[i].Int() < keys[j].Int()
+		default:
+			return keys[i].Uint() < keys[j].Uint()
+		}
+	})
+}
+
+func encodeMap(val reflect.Value, into io.Writer, order binary.ByteOrder) error {
+	keyKind := val.Type().Key().Kind()
+	if !isSortableMapKey(keyKind) {
+		return fmt.Errorf("map key kind %v is not supported, must be a string or integer", keyKind)
+	}
+
+	keys := val.MapKeys()
+	sortMapKeys(keys, keyKind)
+
+	for _, key := range keys {
+		if err := encode(key, into, order, false); err != nil {
+			return fmt.Errorf("can't encode map key: %w", err)
+		}
+		if err := encode(val.MapIndex(key), into, order, false); err != nil {
+			return fmt.Errorf("can't encode map value: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func decodeMap(val reflect.Value, from io.Reader, ctx *decodeCtx, size int) error {
+	keyKind := val.Type().Key().Kind()
+	if !isSortableMapKey(keyKind) {
+		return fmt.Errorf("map key kind %v is not supported, must be a string or integer", keyKind)
+	}
+
+	keyType := val.Type().Key()
+	elemType := val.Type().Elem()
+
+	m := reflect.MakeMapWithSize(val.Type(), size)
+	for range size {
+		key := reflect.New(keyType).Elem()
+		if err := decode(key, from, ctx, nil, false); err != nil {
+			return fmt.Errorf("can't decode map key: %w", err)
+		}
+
+		elem := reflect.New(elemType).Elem()
+		if err := decode(elem, from, ctx, nil, false); err != nil {
+			return fmt.Errorf("can't decode map value: %w", err)
+		}
+
+		m.SetMapIndex(key, elem)
+	}
+
+	val.Set(m)
+	return nil
+}
@@ -0,0 +1,82 @@
+package sbinary
+
+import (
+	"reflect"
+	"sync"
+)
+
+// fieldPlan is a struct field's sbin tag, parsed once and cached, so that
+// repeated Encode/Decode calls don't re-walk Tag.Get and Field(i) for it.
+type fieldPlan struct {
+	index int
+	name  string
+	opts  tagOptions
+
+	// lenTarget is the index, within the owning structPlan's fields slice,
+	// of the field that this one's decoded value supplies the length for
+	// (i.e. the field named by opts.lenOf), or -1 if this field isn't a
+	// lenof source. Resolved once here so decode doesn't re-look-up the
+	// target by name out of a freshly allocated map on every call.
+	lenTarget int
+}
+
+// structPlan is the cached, pre-parsed layout of a struct type.
+type structPlan struct {
+	fields []fieldPlan
+}
+
+var planCache sync.Map // map[reflect.Type]*structPlan
+
+// planFor returns the cached structPlan for t, building and storing it on
+// first sight of t.
+func planFor(t reflect.Type) *structPlan {
+	if cached, ok := planCache.Load(t); ok {
+		return cached.(*structPlan)
+	}
+
+	plan := buildPlan(t)
+	actual, _ := planCache.LoadOrStore(t, plan)
+	return actual.(*structPlan)
+}
+
+// buildPlan resolves each field's sbin tag once and, for a field tagged
+// `lenof:X`, resolves X to its target's index within the plan up front (see
+// fieldPlan.lenTarget), so decode can thread lengths through a flat slice
+// instead of building and looking names up in a map on every call.
+//
+// This stops short of the fully compiled, unsafe.Offsetof-based plan of
+// per-field encoder/decoder closures that would let encode/decode bypass
+// reflect.Value.Field and the kind switch entirely (the approach gob and
+// hashicorp/go-msgpack take). That would mean rebuilding encode/decode's
+// whole recursive dispatch - which also has to handle arbitrarily nested
+// slices, maps, interfaces and CustomEncoder/CustomDecoder types, not just
+// top-level struct fields - around raw pointers, which isn't something to
+// fold into a tag-caching pass without a test run to catch the regressions
+// it would risk. Left as a follow-up scoped to its own request.
+func buildPlan(t reflect.Type) *structPlan {
+	plan := &structPlan{}
+	byName := make(map[string]int)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		opts := parseTag(field.Tag.Get(_tag))
+
+		if opts.ignore || !field.IsExported() {
+			continue
+		}
+
+		byName[field.Name] = len(plan.fields)
+		plan.fields = append(plan.fields, fieldPlan{index: i, name: field.Name, opts: opts, lenTarget: -1})
+	}
+
+	for i, f := range plan.fields {
+		if f.opts.lenOf == "" {
+			continue
+		}
+		if target, ok := byName[f.opts.lenOf]; ok {
+			plan.fields[i].lenTarget = target
+		}
+	}
+
+	return plan
+}
@@ -0,0 +1,87 @@
+package sbinary
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// HasTypeByte lets a concrete type registered via [Register] override the
+// type byte that [Register] would otherwise assign to it automatically.
+type HasTypeByte interface {
+	TypeByte() byte
+}
+
+// ifaceTypes maps an interface type to the set of type bytes -> concrete
+// types registered for it, used while decoding.
+var ifaceTypes = map[reflect.Type]map[byte]reflect.Type{}
+
+// ifaceTags maps an interface type to the set of concrete types -> type
+// bytes registered for it, used while encoding.
+var ifaceTags = map[reflect.Type]map[reflect.Type]byte{}
+
+// Register makes fields of interface type encodable and decodable by
+// associating the concrete implementations of iface with stable type bytes.
+// iface must be a nil pointer to an interface, e.g.:
+//
+//	Register((*Message)(nil), Ping{}, Pong{})
+//
+// Concretes are assigned sequential type bytes starting at 1, in the order
+// given. A concrete type can pin its own byte by implementing [HasTypeByte].
+//
+// On encode, a registered interface field is written as its type byte
+// followed by the concrete value. On decode, the type byte is read back,
+// the matching concrete type is looked up, allocated and decoded into, and
+// assigned to the interface field. Interface fields whose static type was
+// never passed to Register are left untouched, as before.
+func Register(iface any, concretes ...any) {
+	ifaceType := reflect.TypeOf(iface).Elem()
+
+	byTag, ok := ifaceTypes[ifaceType]
+	if !ok {
+		byTag = make(map[byte]reflect.Type)
+		ifaceTypes[ifaceType] = byTag
+	}
+
+	byType, ok := ifaceTags[ifaceType]
+	if !ok {
+		byType = make(map[reflect.Type]byte)
+		ifaceTags[ifaceType] = byType
+	}
+
+	tags := make([]byte, len(concretes))
+	pinned := make([]bool, len(concretes))
+	taken := make(map[byte]bool, len(concretes))
+
+	for i, concrete := range concretes {
+		if hasTypeByte, ok := concrete.(HasTypeByte); ok {
+			tags[i] = hasTypeByte.TypeByte()
+			pinned[i] = true
+			taken[tags[i]] = true
+		}
+	}
+
+	next := byte(1)
+	for i := range concretes {
+		if pinned[i] {
+			continue
+		}
+
+		for taken[next] {
+			next++
+		}
+		tags[i] = next
+		taken[next] = true
+	}
+
+	for i, concrete := range concretes {
+		concreteType := reflect.TypeOf(concrete)
+		tag := tags[i]
+
+		if existing, ok := byTag[tag]; ok {
+			panic(fmt.Sprintf("sbinary: type byte %d for %v is already registered to %v", tag, concreteType, existing))
+		}
+
+		byTag[tag] = concreteType
+		byType[concreteType] = tag
+	}
+}
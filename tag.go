@@ -0,0 +1,27 @@
+package sbinary
+
+import "strings"
+
+// tagOptions is the parsed form of an `sbin` struct tag, e.g. `sbin:"lenof:Data,varint"`.
+type tagOptions struct {
+	ignore bool
+	lenOf  string
+	varint bool
+}
+
+func parseTag(tag string) tagOptions {
+	var opts tagOptions
+
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case part == "-":
+			opts.ignore = true
+		case part == "varint":
+			opts.varint = true
+		case strings.HasPrefix(part, "lenof:"):
+			opts.lenOf = strings.TrimPrefix(part, "lenof:")
+		}
+	}
+
+	return opts
+}
@@ -0,0 +1,57 @@
+package sbinary
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// writeUvarint writes v to w as a LEB128 unsigned varint, the same format
+// used by [encoding/binary.PutUvarint].
+func writeUvarint(w io.Writer, v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// readUvarint reads a LEB128 unsigned varint from r, one byte at a time,
+// since r is not guaranteed to implement io.ByteReader. Like
+// [encoding/binary.ReadUvarint], it reads at most [binary.MaxVarintLen64]
+// bytes before reporting overflow, so a hostile input with the high bit set
+// forever can't make it loop without bound.
+func readUvarint(r io.Reader) (uint64, error) {
+	var x uint64
+	var s uint
+	var b [1]byte
+
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, fmt.Errorf("can't read varint byte: %w", err)
+		}
+
+		if b[0] < 0x80 {
+			if i == binary.MaxVarintLen64-1 && b[0] > 1 {
+				return 0, fmt.Errorf("varint overflows uint64")
+			}
+			return x | uint64(b[0])<<s, nil
+		}
+
+		x |= uint64(b[0]&0x7f) << s
+		s += 7
+	}
+
+	return 0, fmt.Errorf("varint overflows uint64")
+}
+
+// zigzagEncode maps a signed integer to an unsigned one so that values with
+// small absolute value, regardless of sign, have a small varint encoding.
+func zigzagEncode(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// zigzagDecode reverses [zigzagEncode].
+func zigzagDecode(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}